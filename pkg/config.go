@@ -0,0 +1,136 @@
+package labeler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// matcherKey returns a stable identity for m used to dedup matchers when
+// composing configs together. Two matchers with the same key are
+// considered the same rule even if they come from different sources.
+// It folds in every condition field, including AllOf/AnyOf/Not, so two
+// matchers that only differ in a nested sub-rule are never treated as
+// the same rule.
+func (m LabelMatcher) matcherKey() string {
+	parts := []string{
+		m.Label, m.Title, m.Branch, m.Mergeable, m.SizeBelow, m.SizeAbove,
+		strings.Join(m.Files, "\x1f"),
+		m.AuthorAssociation, m.ReviewState, m.CommentBody, m.IssueBodyRegex,
+		m.ExclusiveGroup,
+	}
+
+	allOf := make([]string, len(m.AllOf))
+	for i, sub := range m.AllOf {
+		allOf[i] = sub.matcherKey()
+	}
+	parts = append(parts, strings.Join(allOf, "\x1f"))
+
+	anyOf := make([]string, len(m.AnyOf))
+	for i, sub := range m.AnyOf {
+		anyOf[i] = sub.matcherKey()
+	}
+	parts = append(parts, strings.Join(anyOf, "\x1f"))
+
+	if m.Not != nil {
+		parts = append(parts, m.Not.matcherKey())
+	} else {
+		parts = append(parts, "")
+	}
+
+	return strings.Join(parts, "\x1e")
+}
+
+// MergeConfigs composes base with overlays, in order, the way Terraform
+// composes config fragments: each overlay is layered on top of what came
+// before it. When an overlay declares one or more matchers for a given
+// Label, those matchers entirely replace whatever base (or an earlier
+// overlay) declared for that Label; a Label that no overlay touches is
+// carried over unchanged. Within the matchers that end up assigned to a
+// Label, exact duplicates (by matcherKey) are dropped, keeping the first
+// occurrence.
+//
+// All non-zero Version fields across base and overlays must agree; a
+// mismatch is reported as an error rather than silently picking one.
+func MergeConfigs(base *LabelerConfigV1, overlays ...*LabelerConfigV1) (*LabelerConfigV1, error) {
+	configs := append([]*LabelerConfigV1{base}, overlays...)
+
+	version := 0
+	order := []string{}
+	seenLabel := map[string]bool{}
+	groups := map[string][]LabelMatcher{}
+	keysSeen := map[string]map[string]bool{}
+
+	for _, cfg := range configs {
+		if cfg == nil {
+			continue
+		}
+
+		if cfg.Version != 0 {
+			if version == 0 {
+				version = cfg.Version
+			} else if version != cfg.Version {
+				return nil, fmt.Errorf("labeler: conflicting config versions: %d and %d", version, cfg.Version)
+			}
+		}
+
+		replacedInThisConfig := map[string]bool{}
+		for _, m := range cfg.Labels {
+			if !replacedInThisConfig[m.Label] {
+				groups[m.Label] = nil
+				keysSeen[m.Label] = map[string]bool{}
+				replacedInThisConfig[m.Label] = true
+			}
+
+			key := m.matcherKey()
+			if keysSeen[m.Label][key] {
+				continue
+			}
+			keysSeen[m.Label][key] = true
+
+			groups[m.Label] = append(groups[m.Label], m)
+			if !seenLabel[m.Label] {
+				seenLabel[m.Label] = true
+				order = append(order, m.Label)
+			}
+		}
+	}
+
+	merged := &LabelerConfigV1{Version: version}
+	for _, label := range order {
+		merged.Labels = append(merged.Labels, groups[label]...)
+	}
+	return merged, nil
+}
+
+// NewComposedConfigFetcher builds a FetchRepoConfig implementation that
+// layers an org-wide default config on top of an embedded fallback, then
+// layers the repo's own config on top of that, via MergeConfigs. This
+// lets an organization ship policy once, in a designated `.github` repo,
+// instead of duplicating labeler.yml across every repo.
+//
+// fetchYAML loads a single labeler.yml for the given owner/repo (e.g. by
+// reading .github/labeler.yml from that repo's default branch); it is
+// reused for both the target repo and, when orgRepo is non-empty, the
+// org's `.github` repo. embeddedDefault may be nil.
+func NewComposedConfigFetcher(
+	fetchYAML func(owner, repoName string) (*LabelerConfigV1, error),
+	orgRepo string,
+	embeddedDefault *LabelerConfigV1,
+) func(owner, repoName string) (*LabelerConfigV1, error) {
+	return func(owner, repoName string) (*LabelerConfigV1, error) {
+		repoCfg, err := fetchYAML(owner, repoName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch config for %s/%s: %w", owner, repoName, err)
+		}
+
+		var orgCfg *LabelerConfigV1
+		if orgRepo != "" && repoName != orgRepo {
+			orgCfg, err = fetchYAML(owner, orgRepo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch org-wide config from %s/%s: %w", owner, orgRepo, err)
+			}
+		}
+
+		return MergeConfigs(embeddedDefault, orgCfg, repoCfg)
+	}
+}
@@ -0,0 +1,176 @@
+package labeler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeConfigs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		base     *LabelerConfigV1
+		overlays []*LabelerConfigV1
+		expected *LabelerConfigV1
+		wantErr  bool
+	}{
+		{
+			name:     "nil base and no overlays",
+			base:     nil,
+			overlays: nil,
+			expected: &LabelerConfigV1{},
+		},
+		{
+			name: "overlay extends base with a new label",
+			base: &LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			overlays: []*LabelerConfigV1{
+				{
+					Labels: []LabelMatcher{
+						{Label: "Docs", Files: []string{"^docs/.*"}},
+					},
+				},
+			},
+			expected: &LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+					{Label: "Docs", Files: []string{"^docs/.*"}},
+				},
+			},
+		},
+		{
+			name: "overlay replaces base matchers for the same label",
+			base: &LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "Size", SizeBelow: "10"},
+				},
+			},
+			overlays: []*LabelerConfigV1{
+				{
+					Labels: []LabelMatcher{
+						{Label: "Size", SizeBelow: "50"},
+					},
+				},
+			},
+			expected: &LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "Size", SizeBelow: "50"},
+				},
+			},
+		},
+		{
+			name: "duplicate matchers are deduped by matcher key",
+			base: &LabelerConfigV1{
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			overlays: []*LabelerConfigV1{
+				{
+					Labels: []LabelMatcher{
+						{Label: "WIP", Title: "^WIP:.*"},
+						{Label: "WIP", Branch: "^wip/.*"},
+						{Label: "WIP", Title: "^WIP:.*"},
+					},
+				},
+			},
+			expected: &LabelerConfigV1{
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+					{Label: "WIP", Branch: "^wip/.*"},
+				},
+			},
+		},
+		{
+			name: "matchers differing only in a nested AnyOf are not deduped",
+			base: &LabelerConfigV1{
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			overlays: []*LabelerConfigV1{
+				{
+					Labels: []LabelMatcher{
+						{Label: "Backend", AnyOf: []LabelMatcher{{Files: []string{"^api/.*"}}}},
+						{Label: "Backend", AnyOf: []LabelMatcher{{Files: []string{"^db/.*"}}}},
+					},
+				},
+			},
+			expected: &LabelerConfigV1{
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+					{Label: "Backend", AnyOf: []LabelMatcher{{Files: []string{"^api/.*"}}}},
+					{Label: "Backend", AnyOf: []LabelMatcher{{Files: []string{"^db/.*"}}}},
+				},
+			},
+		},
+		{
+			name: "conflicting versions are rejected",
+			base: &LabelerConfigV1{Version: 1},
+			overlays: []*LabelerConfigV1{
+				{Version: 2},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := MergeConfigs(tc.base, tc.overlays...)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("%s: expected an error, got none", tc.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.name, err)
+			}
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Fatalf("%s: expected %+v, got %+v", tc.name, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewComposedConfigFetcher(t *testing.T) {
+	repoConfig := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "Backend", Files: []string{"^pkg/.*"}},
+		},
+	}
+	orgConfig := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "NeedsTriage", Title: "^.*"},
+		},
+	}
+
+	fetch := NewComposedConfigFetcher(
+		func(owner, repoName string) (*LabelerConfigV1, error) {
+			if repoName == ".github" {
+				return orgConfig, nil
+			}
+			return repoConfig, nil
+		},
+		".github",
+		nil,
+	)
+
+	got, err := fetch("acme", "widgets")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, _ := MergeConfigs(nil, orgConfig, repoConfig)
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
@@ -0,0 +1,230 @@
+package labeler
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EvaluationContext is the normalized view of an incoming webhook event
+// that matchers evaluate against. Each event type extractor below
+// populates only the fields that make sense for it; the rest are left
+// at their zero value, so an exact-match condition on a field that
+// doesn't apply to the event type never matches, and a regex condition
+// matches only if it matches the empty string (see LabelMatcher for
+// which fields apply to which events). Size is the exception: its zero
+// value, 0, is itself a
+// meaningful size, so extractors for events that don't carry a size set
+// it to -1 instead, and SizeBelow/SizeAbove treat a negative Size as
+// "not applicable" rather than matching it.
+type EvaluationContext struct {
+	Title             string
+	Branch            string
+	Mergeable         string
+	Size              int
+	Files             []string
+	AuthorAssociation string
+	ReviewState       string
+	CommentBody       string
+	IssueBody         string
+}
+
+type repositoryRef struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// pullRequestPayload is the subset of the GitHub pull_request webhook
+// payload that the labeler cares about. Files is not part of the stock
+// webhook payload; it is only set when the caller adds the PR's changed
+// file paths to the payload under "files" before handing it over.
+type pullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title        string `json:"title"`
+		Mergeable    *bool  `json:"mergeable"`
+		Additions    int    `json:"additions"`
+		Deletions    int    `json:"deletions"`
+		ChangedFiles int    `json:"changed_files"`
+		Head         struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository repositoryRef `json:"repository"`
+	Files      []string      `json:"files"`
+}
+
+func (p *pullRequestPayload) context() *EvaluationContext {
+	ctx := &EvaluationContext{
+		Title:  p.PullRequest.Title,
+		Branch: p.PullRequest.Head.Ref,
+		Size:   p.PullRequest.Additions + p.PullRequest.Deletions,
+		Files:  p.Files,
+	}
+	if p.PullRequest.Mergeable != nil {
+		if *p.PullRequest.Mergeable {
+			ctx.Mergeable = "True"
+		} else {
+			ctx.Mergeable = "False"
+		}
+	} else {
+		ctx.Mergeable = "Unknown"
+	}
+	return ctx
+}
+
+// issuePayload is the subset of the GitHub issues webhook payload the
+// labeler cares about.
+type issuePayload struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number            int    `json:"number"`
+		Title             string `json:"title"`
+		Body              string `json:"body"`
+		AuthorAssociation string `json:"author_association"`
+	} `json:"issue"`
+	Repository repositoryRef `json:"repository"`
+}
+
+func (p *issuePayload) context() *EvaluationContext {
+	return &EvaluationContext{
+		Title:             p.Issue.Title,
+		IssueBody:         p.Issue.Body,
+		AuthorAssociation: p.Issue.AuthorAssociation,
+		Size:              -1,
+	}
+}
+
+// issueCommentPayload is the subset of the GitHub issue_comment webhook
+// payload the labeler cares about. It fires for comments on both issues
+// and pull requests.
+type issueCommentPayload struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+	} `json:"issue"`
+	Comment struct {
+		Body              string `json:"body"`
+		AuthorAssociation string `json:"author_association"`
+	} `json:"comment"`
+	Repository repositoryRef `json:"repository"`
+}
+
+func (p *issueCommentPayload) context() *EvaluationContext {
+	return &EvaluationContext{
+		Title:             p.Issue.Title,
+		IssueBody:         p.Issue.Body,
+		CommentBody:       p.Comment.Body,
+		AuthorAssociation: p.Comment.AuthorAssociation,
+		Size:              -1,
+	}
+}
+
+// pullRequestRef is the subset of the pull_request object embedded in
+// review and review-comment payloads.
+type pullRequestRef struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Head   struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+}
+
+// pullRequestReviewPayload is the subset of the GitHub
+// pull_request_review webhook payload the labeler cares about.
+type pullRequestReviewPayload struct {
+	Action string `json:"action"`
+	Review struct {
+		State             string `json:"state"`
+		Body              string `json:"body"`
+		AuthorAssociation string `json:"author_association"`
+	} `json:"review"`
+	PullRequest pullRequestRef `json:"pull_request"`
+	Repository  repositoryRef  `json:"repository"`
+}
+
+func (p *pullRequestReviewPayload) context() *EvaluationContext {
+	return &EvaluationContext{
+		Title:             p.PullRequest.Title,
+		Branch:            p.PullRequest.Head.Ref,
+		ReviewState:       p.Review.State,
+		CommentBody:       p.Review.Body,
+		AuthorAssociation: p.Review.AuthorAssociation,
+		Size:              -1,
+	}
+}
+
+// pullRequestReviewCommentPayload is the subset of the GitHub
+// pull_request_review_comment webhook payload the labeler cares about.
+type pullRequestReviewCommentPayload struct {
+	Action  string `json:"action"`
+	Comment struct {
+		Body              string `json:"body"`
+		AuthorAssociation string `json:"author_association"`
+	} `json:"comment"`
+	PullRequest pullRequestRef `json:"pull_request"`
+	Repository  repositoryRef  `json:"repository"`
+}
+
+func (p *pullRequestReviewCommentPayload) context() *EvaluationContext {
+	return &EvaluationContext{
+		Title:             p.PullRequest.Title,
+		Branch:            p.PullRequest.Head.Ref,
+		CommentBody:       p.Comment.Body,
+		AuthorAssociation: p.Comment.AuthorAssociation,
+		Size:              -1,
+	}
+}
+
+// parseEvent dispatches a raw webhook payload to the extractor for
+// eventType and returns the owner/repo/number it's for along with the
+// EvaluationContext matchers should evaluate. It returns a nil context
+// (and nil error) for event types the labeler doesn't act on.
+func parseEvent(eventType string, payload *[]byte) (owner, repoName string, number int, ctx *EvaluationContext, err error) {
+	switch eventType {
+	case "pull_request":
+		var p pullRequestPayload
+		if err := json.Unmarshal(*payload, &p); err != nil {
+			return "", "", 0, nil, fmt.Errorf("failed to parse pull_request payload: %w", err)
+		}
+		return p.Repository.Owner.Login, p.Repository.Name, p.Number, p.context(), nil
+
+	case "issues":
+		var p issuePayload
+		if err := json.Unmarshal(*payload, &p); err != nil {
+			return "", "", 0, nil, fmt.Errorf("failed to parse issues payload: %w", err)
+		}
+		return p.Repository.Owner.Login, p.Repository.Name, p.Issue.Number, p.context(), nil
+
+	case "issue_comment":
+		var p issueCommentPayload
+		if err := json.Unmarshal(*payload, &p); err != nil {
+			return "", "", 0, nil, fmt.Errorf("failed to parse issue_comment payload: %w", err)
+		}
+		return p.Repository.Owner.Login, p.Repository.Name, p.Issue.Number, p.context(), nil
+
+	case "pull_request_review":
+		var p pullRequestReviewPayload
+		if err := json.Unmarshal(*payload, &p); err != nil {
+			return "", "", 0, nil, fmt.Errorf("failed to parse pull_request_review payload: %w", err)
+		}
+		return p.Repository.Owner.Login, p.Repository.Name, p.PullRequest.Number, p.context(), nil
+
+	case "pull_request_review_comment":
+		var p pullRequestReviewCommentPayload
+		if err := json.Unmarshal(*payload, &p); err != nil {
+			return "", "", 0, nil, fmt.Errorf("failed to parse pull_request_review_comment payload: %w", err)
+		}
+		return p.Repository.Owner.Login, p.Repository.Name, p.PullRequest.Number, p.context(), nil
+
+	default:
+		return "", "", 0, nil, nil
+	}
+}
@@ -0,0 +1,493 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// LabelMatcher describes a single rule: apply Label when its conditions
+// are satisfied. Several matchers may share the same Label, in which
+// case they are OR'd together: the label is applied if any of them
+// match.
+//
+// The flat fields (Title, Branch, Mergeable, SizeBelow, SizeAbove,
+// Files, AuthorAssociation, ReviewState, CommentBody, IssueBodyRegex)
+// are sugar for a single-node AllOf: a matcher using only flat fields
+// matches when every one of them, plus every matcher in AllOf, is
+// satisfied. AnyOf and Not add boolean combinators on top of that:
+// AnyOf matches when at least one of its nested matchers matches, and
+// Not matches when its nested matcher does not. A matcher with no
+// condition set anywhere (flat fields, AllOf, AnyOf, Not) never matches.
+//
+// Not every field applies to every event type HandleEvent accepts:
+// Mergeable, SizeBelow, SizeAbove and Files only make sense for
+// pull_request events; Branch for pull_request, pull_request_review and
+// pull_request_review_comment; ReviewState only for pull_request_review;
+// CommentBody for issue_comment, pull_request_review (the review body)
+// and pull_request_review_comment; IssueBodyRegex for issues and
+// issue_comment; AuthorAssociation for every event except pull_request.
+// A field that doesn't apply to the event being evaluated is left empty
+// in the EvaluationContext, so an exact-match condition on it fails, as
+// does a regex that cannot match the empty string.
+type LabelMatcher struct {
+	Label     string         `yaml:"label" json:"label"`
+	Title     string         `yaml:"title,omitempty" json:"title,omitempty"`
+	Branch    string         `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Mergeable string         `yaml:"mergeable,omitempty" json:"mergeable,omitempty"`
+	SizeBelow string         `yaml:"size_below,omitempty" json:"size_below,omitempty"`
+	SizeAbove string         `yaml:"size_above,omitempty" json:"size_above,omitempty"`
+	Files     []string       `yaml:"files,omitempty" json:"files,omitempty"`
+	AllOf     []LabelMatcher `yaml:"all_of,omitempty" json:"all_of,omitempty"`
+	AnyOf     []LabelMatcher `yaml:"any_of,omitempty" json:"any_of,omitempty"`
+	Not       *LabelMatcher  `yaml:"not,omitempty" json:"not,omitempty"`
+
+	// AuthorAssociation matches the GitHub author_association of the
+	// event's actor (e.g. "OWNER", "MEMBER", "CONTRIBUTOR"), exactly.
+	AuthorAssociation string `yaml:"author_association,omitempty" json:"author_association,omitempty"`
+	// ReviewState matches a pull_request_review's state (e.g.
+	// "approved", "changes_requested", "commented"), exactly.
+	ReviewState string `yaml:"review_state,omitempty" json:"review_state,omitempty"`
+	// CommentBody is a regex matched against an issue_comment's or
+	// pull_request_review_comment's body.
+	CommentBody string `yaml:"comment_body,omitempty" json:"comment_body,omitempty"`
+	// IssueBodyRegex is a regex matched against an issue's body.
+	IssueBodyRegex string `yaml:"issue_body_regex,omitempty" json:"issue_body_regex,omitempty"`
+
+	// ExclusiveGroup, when set, marks this matcher as mutually exclusive
+	// with every other matcher sharing the same group name (e.g. size
+	// buckets "S"/"M"/"L" all set ExclusiveGroup: "size"). If more than
+	// one matcher in a group matches the same PR, only the most
+	// specific one wins; see resolveConflicts.
+	ExclusiveGroup string `yaml:"exclusive_group,omitempty" json:"exclusive_group,omitempty"`
+}
+
+// specificity is the number of conditions m sets, used to rank matchers
+// against each other when more than one fires for the same exclusive
+// group. It intentionally looks only at m's own fields, not at nested
+// AllOf/AnyOf/Not matchers.
+func (m LabelMatcher) specificity() int {
+	n := 0
+	if m.Title != "" {
+		n++
+	}
+	if m.Branch != "" {
+		n++
+	}
+	if m.Mergeable != "" {
+		n++
+	}
+	if m.SizeBelow != "" {
+		n++
+	}
+	if m.SizeAbove != "" {
+		n++
+	}
+	if len(m.Files) > 0 {
+		n++
+	}
+	if m.AuthorAssociation != "" {
+		n++
+	}
+	if m.ReviewState != "" {
+		n++
+	}
+	if m.CommentBody != "" {
+		n++
+	}
+	if m.IssueBodyRegex != "" {
+		n++
+	}
+	if len(m.AllOf) > 0 {
+		n++
+	}
+	if len(m.AnyOf) > 0 {
+		n++
+	}
+	if m.Not != nil {
+		n++
+	}
+	return n
+}
+
+// LabelerConfigV1 is the schema of .github/labeler.yml.
+type LabelerConfigV1 struct {
+	Version int            `yaml:"version" json:"version"`
+	Labels  []LabelMatcher `yaml:"label" json:"labels"`
+}
+
+// Labeler evaluates a LabelerConfigV1 against incoming GitHub events and
+// reconciles the labels on the affected issue or pull request. The three
+// funcs are injected so callers can back them with the GitHub API in
+// production and with fakes in tests.
+type Labeler struct {
+	FetchRepoConfig    func(owner, repoName string) (*LabelerConfigV1, error)
+	GetCurrentLabels   func(owner, repoName string, prNumber int) ([]string, error)
+	ReplaceLabelsForPr func(owner, repoName string, prNumber int, labels []string) error
+
+	// OnConflict, when set, is called whenever more than one matcher in
+	// the same exclusive group matches the affected issue or pull
+	// request. winners holds the matcher that was applied (always
+	// exactly one), losers the rest, sorted by descending specificity
+	// with declaration order breaking ties.
+	OnConflict func(label string, winners, losers []LabelMatcher)
+
+	// ValidationRules configures Validate. The zero value falls back to
+	// DefaultValidationRules.
+	ValidationRules ValidationRules
+	// StrictValidation, when true, makes HandleEvent refuse to apply any
+	// labels if the fetched config fails validation. When false (the
+	// default, permissive mode), only the offending matchers are
+	// skipped and the rest of the config is still applied.
+	StrictValidation bool
+
+	// DryRun, when true, makes HandleEvent run the full matcher pipeline
+	// but skip the call to ReplaceLabelsForPr, so callers can wire it up
+	// behind a flag without granting write scope. Use PreviewEvent to
+	// get at what would have changed.
+	DryRun bool
+}
+
+// HandleEvent parses a webhook payload, evaluates the repo's label config
+// against it, and reconciles the resulting labels on the affected issue
+// or pull request. If l.DryRun is set, the pipeline runs in full but
+// ReplaceLabelsForPr is never called; use PreviewEvent to see what it
+// would have done.
+func (l *Labeler) HandleEvent(eventType string, payload *[]byte) error {
+	plan, err := l.plan(eventType, payload)
+	if err != nil {
+		return err
+	}
+	if plan == nil || l.DryRun {
+		return nil
+	}
+
+	desired := append(append([]string{}, plan.changeSet.Kept...), plan.changeSet.Added...)
+	return l.ReplaceLabelsForPr(plan.owner, plan.repoName, plan.prNumber, desired)
+}
+
+// eventPlan is the outcome of evaluating a webhook payload against its
+// repo's config: who it's for, and the change set that would reconcile
+// current labels with what the config says they should be.
+type eventPlan struct {
+	owner, repoName string
+	prNumber        int
+	cfg             *LabelerConfigV1
+	changeSet       *LabelChangeSet
+}
+
+// plan parses payload, fetches and validates the repo's config, and
+// evaluates it against the subject's current labels. It returns a nil
+// plan (and nil error) for event types HandleEvent/PreviewEvent don't
+// act on.
+func (l *Labeler) plan(eventType string, payload *[]byte) (*eventPlan, error) {
+	owner, repoName, number, ctx, err := parseEvent(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		return nil, nil
+	}
+
+	cfg, err := l.FetchRepoConfig(owner, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repo config: %w", err)
+	}
+
+	cfg, err = l.validateOrFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := l.GetCurrentLabels(owner, repoName, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current labels for #%d: %w", number, err)
+	}
+
+	changeSet, err := buildChangeSet(cfg, ctx, current, l.OnConflict)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventPlan{
+		owner:     owner,
+		repoName:  repoName,
+		prNumber:  number,
+		cfg:       cfg,
+		changeSet: changeSet,
+	}, nil
+}
+
+// validateOrFilter runs cfg through Validate. In strict mode any
+// violation aborts with a *ConfigValidationError. In permissive mode
+// (the default) the offending matchers are dropped and the rest of cfg
+// is returned unchanged.
+func (l *Labeler) validateOrFilter(cfg *LabelerConfigV1) (*LabelerConfigV1, error) {
+	err := l.Validate(cfg)
+	if err == nil {
+		return cfg, nil
+	}
+	if l.StrictValidation {
+		return nil, err
+	}
+
+	// Drop later duplicates first, so that every remaining violation is
+	// about a label name, which all matchers for that label share.
+	deduped := make([]LabelMatcher, 0, len(cfg.Labels))
+	seenKeys := map[string]bool{}
+	for _, m := range cfg.Labels {
+		if key := m.matcherKey(); !seenKeys[key] {
+			seenKeys[key] = true
+			deduped = append(deduped, m)
+		}
+	}
+
+	bad := violatingLabels(l.Validate(&LabelerConfigV1{Labels: deduped}))
+	clean := &LabelerConfigV1{Version: cfg.Version}
+	for _, m := range deduped {
+		if !bad[m.Label] {
+			clean.Labels = append(clean.Labels, m)
+		}
+	}
+	return clean, nil
+}
+
+// resolveConflicts evaluates every matcher in cfg against ctx and
+// returns the set of labels that should be applied, plus the matcher(s)
+// responsible for each one. A matching matcher without an ExclusiveGroup
+// applies its label directly, so multiple matchers for one label still
+// OR together as before, and onConflict is never called for them.
+// Matchers that set ExclusiveGroup are grouped by it; when more than one
+// matcher in a group matches, the most specific one wins (ties broken by
+// declaration order) and onConflict, if set, is told about the winner
+// and the losers.
+func resolveConflicts(cfg *LabelerConfigV1, ctx *EvaluationContext, onConflict func(string, []LabelMatcher, []LabelMatcher)) (map[string]bool, map[string][]LabelMatcher, error) {
+	type candidate struct {
+		idx int
+		m   LabelMatcher
+	}
+
+	groups := map[string][]candidate{}
+	var groupOrder []string
+	seenGroup := map[string]bool{}
+
+	matched := map[string]bool{}
+	matchedRules := map[string][]LabelMatcher{}
+
+	for i, m := range cfg.Labels {
+		ok, err := m.matches(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate matcher for label %q: %w", m.Label, err)
+		}
+		if !ok {
+			continue
+		}
+
+		// Matchers only compete for conflict resolution when they
+		// explicitly opt in via ExclusiveGroup. Matchers that merely
+		// share a Label (the pre-existing implicit-OR pattern) OR
+		// together silently, with no OnConflict call, same as always.
+		if m.ExclusiveGroup == "" {
+			matched[m.Label] = true
+			matchedRules[m.Label] = append(matchedRules[m.Label], m)
+			continue
+		}
+
+		group := m.ExclusiveGroup
+		if !seenGroup[group] {
+			seenGroup[group] = true
+			groupOrder = append(groupOrder, group)
+		}
+		groups[group] = append(groups[group], candidate{i, m})
+	}
+
+	for _, group := range groupOrder {
+		cands := groups[group]
+		if len(cands) == 1 {
+			matched[cands[0].m.Label] = true
+			matchedRules[cands[0].m.Label] = append(matchedRules[cands[0].m.Label], cands[0].m)
+			continue
+		}
+
+		sort.SliceStable(cands, func(i, j int) bool {
+			si, sj := cands[i].m.specificity(), cands[j].m.specificity()
+			if si != sj {
+				return si > sj
+			}
+			return cands[i].idx < cands[j].idx
+		})
+
+		winner := cands[0]
+		matched[winner.m.Label] = true
+		matchedRules[winner.m.Label] = append(matchedRules[winner.m.Label], winner.m)
+
+		if onConflict != nil {
+			losers := make([]LabelMatcher, 0, len(cands)-1)
+			for _, c := range cands[1:] {
+				losers = append(losers, c.m)
+			}
+			onConflict(winner.m.Label, []LabelMatcher{winner.m}, losers)
+		}
+	}
+
+	return matched, matchedRules, nil
+}
+
+// matches reports whether every condition set on m holds for ctx. A
+// matcher with no conditions set never matches, so an empty LabelMatcher
+// in a config is a no-op rather than an always-true rule.
+func (m *LabelMatcher) matches(ctx *EvaluationContext) (bool, error) {
+	any := false
+
+	if m.Title != "" {
+		any = true
+		ok, err := regexp.MatchString(m.Title, ctx.Title)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	if m.Branch != "" {
+		any = true
+		ok, err := regexp.MatchString(m.Branch, ctx.Branch)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	if m.Mergeable != "" {
+		any = true
+		if ctx.Mergeable != m.Mergeable {
+			return false, nil
+		}
+	}
+
+	if m.SizeBelow != "" {
+		any = true
+		n, err := strconv.Atoi(m.SizeBelow)
+		if err != nil {
+			return false, err
+		}
+		if ctx.Size < 0 || !(ctx.Size < n) {
+			return false, nil
+		}
+	}
+
+	if m.SizeAbove != "" {
+		any = true
+		n, err := strconv.Atoi(m.SizeAbove)
+		if err != nil {
+			return false, err
+		}
+		if ctx.Size < 0 || !(ctx.Size > n) {
+			return false, nil
+		}
+	}
+
+	if len(m.Files) > 0 {
+		any = true
+		if !anyFileMatches(m.Files, ctx.Files) {
+			return false, nil
+		}
+	}
+
+	if m.AuthorAssociation != "" {
+		any = true
+		if ctx.AuthorAssociation != m.AuthorAssociation {
+			return false, nil
+		}
+	}
+
+	if m.ReviewState != "" {
+		any = true
+		if ctx.ReviewState != m.ReviewState {
+			return false, nil
+		}
+	}
+
+	if m.CommentBody != "" {
+		any = true
+		ok, err := regexp.MatchString(m.CommentBody, ctx.CommentBody)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	if m.IssueBodyRegex != "" {
+		any = true
+		ok, err := regexp.MatchString(m.IssueBodyRegex, ctx.IssueBody)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	if len(m.AllOf) > 0 {
+		any = true
+		for _, sub := range m.AllOf {
+			ok, err := sub.matches(ctx)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+
+	if len(m.AnyOf) > 0 {
+		any = true
+		matchedAny := false
+		for _, sub := range m.AnyOf {
+			ok, err := sub.matches(ctx)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matchedAny = true
+				break
+			}
+		}
+		if !matchedAny {
+			return false, nil
+		}
+	}
+
+	if m.Not != nil {
+		any = true
+		ok, err := m.Not.matches(ctx)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+	}
+
+	return any, nil
+}
+
+// anyFileMatches reports whether any pattern in patterns matches any path
+// in files.
+func anyFileMatches(patterns, files []string) bool {
+	for _, pattern := range patterns {
+		for _, f := range files {
+			if ok, err := regexp.MatchString(pattern, f); err == nil && ok {
+				return true
+			}
+		}
+	}
+	return false
+}
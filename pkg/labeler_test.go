@@ -18,7 +18,11 @@ func loadPayload(name string) ([]byte, error) {
 }
 
 type TestCase struct {
-	payloads       []string
+	payloads []string
+	// eventType is the webhook event name to hand to HandleEvent. Empty
+	// defaults to "pull_request", the event all the pre-existing cases
+	// were written against.
+	eventType      string
 	name           string
 	config         LabelerConfigV1
 	initialLabels  []string
@@ -276,6 +280,317 @@ func TestHandleEvent(t *testing.T) {
 			initialLabels:  []string{},
 			expectedLabels: []string{"Branch"},
 		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "AllOf matches when every nested matcher matches",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						AllOf: []LabelMatcher{
+							{Title: "^WIP:.*"},
+							{Mergeable: "False"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Backend"},
+		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "AllOf short-circuits on the first nested mismatch",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						AllOf: []LabelMatcher{
+							{Title: "^WIP:.*"},
+							{Mergeable: "True"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "AllOf short-circuits before evaluating an invalid regex in a later item",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						AllOf: []LabelMatcher{
+							{Mergeable: "True"},
+							{Title: "(invalid["},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "AllOf containing an AnyOf containing a Not matches at depth",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						AllOf: []LabelMatcher{
+							{Title: "^WIP:.*"},
+							{
+								AnyOf: []LabelMatcher{
+									{Branch: "^does-not-match$"},
+									{Not: &LabelMatcher{Branch: "^hotfix/.*"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Backend"},
+		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "AnyOf matches when one nested matcher matches",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Api",
+						AnyOf: []LabelMatcher{
+							{Title: "^DOES NOT MATCH:.*"},
+							{Branch: "^feature-foo$"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Api"},
+		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "AnyOf does not match when no nested matcher matches",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Api",
+						AnyOf: []LabelMatcher{
+							{Title: "^DOES NOT MATCH:.*"},
+							{Branch: "^does-not-match$"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		TestCase{
+			payloads: []string{"create_pr"},
+			name:     "Not lets the match through when its nested matcher does not match",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						Title: "^WIP:.*",
+						Not:   &LabelMatcher{Branch: "^hotfix/.*"},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Backend"},
+		},
+		TestCase{
+			payloads: []string{"hotfix_pr"},
+			name:     "Not blocks the match when its nested matcher matches",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						Title: "^Fix:.*",
+						Not:   &LabelMatcher{Branch: "^hotfix/.*"},
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		TestCase{
+			payloads: []string{"hotfix_pr"},
+			name:     "Implicit OR across matchers still applies alongside Not",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label: "Backend",
+						Title: "^Fix:.*",
+						Not:   &LabelMatcher{Branch: "^hotfix/.*"},
+					},
+					LabelMatcher{
+						Label:  "Backend",
+						Branch: "^hotfix/.*",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Backend"},
+		},
+		TestCase{
+			payloads: []string{"small_pr"},
+			name:     "Mutually exclusive size buckets: the more specific matcher wins on a boundary",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:          "S",
+						SizeBelow:      "10",
+						ExclusiveGroup: "size",
+					},
+					LabelMatcher{
+						Label:          "M",
+						SizeAbove:      "3",
+						SizeBelow:      "20",
+						ExclusiveGroup: "size",
+					},
+					LabelMatcher{
+						Label:          "L",
+						SizeAbove:      "100",
+						ExclusiveGroup: "size",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"M"},
+		},
+		TestCase{
+			payloads: []string{"small_pr"},
+			name:     "Mutually exclusive size buckets: declaration order breaks a specificity tie",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:          "First",
+						SizeBelow:      "10",
+						ExclusiveGroup: "size",
+					},
+					LabelMatcher{
+						Label:          "Second",
+						SizeBelow:      "20",
+						ExclusiveGroup: "size",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"First"},
+		},
+		TestCase{
+			payloads:  []string{"open_issue"},
+			eventType: "issues",
+			name:      "issues: IssueBodyRegex and AuthorAssociation match the issue body and author",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:             "Regression",
+						IssueBodyRegex:    "regression",
+						AuthorAssociation: "CONTRIBUTOR",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Regression"},
+		},
+		TestCase{
+			payloads:  []string{"open_issue"},
+			eventType: "issues",
+			name:      "issues: AuthorAssociation mismatch does not match",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:             "Regression",
+						IssueBodyRegex:    "regression",
+						AuthorAssociation: "OWNER",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		TestCase{
+			payloads:  []string{"issue_comment"},
+			eventType: "issue_comment",
+			name:      "issue_comment: CommentBody matches a comment on an issue",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:       "NeedsRepro",
+						CommentBody: "^/label needs-repro$",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"NeedsRepro"},
+		},
+		TestCase{
+			payloads:  []string{"pr_review"},
+			eventType: "pull_request_review",
+			name:      "pull_request_review: ReviewState matches a submitted review",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:       "ChangesRequested",
+						ReviewState: "changes_requested",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"ChangesRequested"},
+		},
+		TestCase{
+			payloads:  []string{"pr_review"},
+			eventType: "pull_request_review",
+			name:      "pull_request_review: ReviewState mismatch does not match",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:       "Approved",
+						ReviewState: "approved",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		TestCase{
+			payloads:  []string{"pr_review_comment"},
+			eventType: "pull_request_review_comment",
+			name:      "pull_request_review_comment: CommentBody matches a review comment",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					LabelMatcher{
+						Label:       "Nitpick",
+						CommentBody: "^nit:.*",
+					},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Nitpick"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -286,8 +601,12 @@ func TestHandleEvent(t *testing.T) {
 			}
 
 			fmt.Println(tc.name)
+			eventType := tc.eventType
+			if eventType == "" {
+				eventType = "pull_request"
+			}
 			l := NewTestLabeler(t, tc)
-			err = l.HandleEvent("pull_request", &payload)
+			err = l.HandleEvent(eventType, &payload)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -314,3 +633,88 @@ func NewTestLabeler(t *testing.T, tc TestCase) Labeler {
 		},
 	}
 }
+
+func TestHandleEventReportsConflicts(t *testing.T) {
+	payload, err := loadPayload("small_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotLabel string
+	var gotWinners, gotLosers []LabelMatcher
+
+	l := Labeler{
+		FetchRepoConfig: func(owner, repoName string) (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "S", SizeBelow: "10", ExclusiveGroup: "size"},
+					{Label: "M", SizeAbove: "3", SizeBelow: "20", ExclusiveGroup: "size"},
+				},
+			}, nil
+		},
+		GetCurrentLabels: func(owner, repoName string, prNumber int) ([]string, error) {
+			return []string{}, nil
+		},
+		ReplaceLabelsForPr: func(owner, repoName string, prNumber int, labels []string) error {
+			return nil
+		},
+		OnConflict: func(label string, winners, losers []LabelMatcher) {
+			gotLabel = label
+			gotWinners = winners
+			gotLosers = losers
+		},
+	}
+
+	if err := l.HandleEvent("pull_request", &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if gotLabel != "M" {
+		t.Fatalf("expected the conflict to report \"M\" as the winning label, got %q", gotLabel)
+	}
+	if len(gotWinners) != 1 || gotWinners[0].Label != "M" {
+		t.Fatalf("expected a single winner matcher for M, got %+v", gotWinners)
+	}
+	if len(gotLosers) != 1 || gotLosers[0].Label != "S" {
+		t.Fatalf("expected S to be reported as the loser, got %+v", gotLosers)
+	}
+}
+
+func TestHandleEventImplicitOrAcrossSameLabelMatchersDoesNotReportConflicts(t *testing.T) {
+	payload, err := loadPayload("small_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conflictCalls := 0
+
+	l := Labeler{
+		FetchRepoConfig: func(owner, repoName string) (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "Backend", Title: "^Small.*"},
+					{Label: "Backend", Mergeable: "True"},
+				},
+			}, nil
+		},
+		GetCurrentLabels: func(owner, repoName string, prNumber int) ([]string, error) {
+			return []string{}, nil
+		},
+		ReplaceLabelsForPr: func(owner, repoName string, prNumber int, labels []string) error {
+			return nil
+		},
+		OnConflict: func(label string, winners, losers []LabelMatcher) {
+			conflictCalls++
+		},
+	}
+
+	if err := l.HandleEvent("pull_request", &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if conflictCalls != 0 {
+		t.Fatalf("expected no OnConflict calls for ordinary same-label matchers, got %d", conflictCalls)
+	}
+}
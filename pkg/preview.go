@@ -0,0 +1,74 @@
+package labeler
+
+// LabelChangeSet is the result of running the matcher pipeline against
+// the affected issue or pull request without mutating it: what would be
+// added, removed, and left alone, plus which matchers are responsible
+// for each label that matched.
+type LabelChangeSet struct {
+	Added        []string
+	Removed      []string
+	Kept         []string
+	MatchedRules map[string][]LabelMatcher
+}
+
+// PreviewEvent runs the same pipeline as HandleEvent but never calls
+// ReplaceLabelsForPr, returning the LabelChangeSet it would have applied
+// instead. It returns a nil change set (and nil error) for event types
+// the labeler doesn't act on. This is what a "labeler-lint" CLI or a
+// check-run comment can use to preview a config change against the
+// affected issue or pull request without write access to it.
+func (l *Labeler) PreviewEvent(eventType string, payload *[]byte) (*LabelChangeSet, error) {
+	plan, err := l.plan(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, nil
+	}
+	return plan.changeSet, nil
+}
+
+// buildChangeSet computes the LabelChangeSet for reconciling current
+// with cfg applied against ctx. A label managed by cfg (referenced by at
+// least one matcher) is applied when any of its matchers match (for a
+// matcher in an ExclusiveGroup, only when it also wins its group): it is
+// added if not already present and kept if it is. A managed label that
+// is not applied is removed if present; labels not mentioned anywhere in
+// cfg are kept untouched. onConflict, if non-nil, is notified whenever
+// resolveConflicts has to pick a winner.
+func buildChangeSet(cfg *LabelerConfigV1, ctx *EvaluationContext, current []string, onConflict func(string, []LabelMatcher, []LabelMatcher)) (*LabelChangeSet, error) {
+	managed := map[string]bool{}
+	for _, m := range cfg.Labels {
+		managed[m.Label] = true
+	}
+
+	matched, matchedRules, err := resolveConflicts(cfg, ctx, onConflict)
+	if err != nil {
+		return nil, err
+	}
+
+	changeSet := &LabelChangeSet{MatchedRules: matchedRules}
+	seen := map[string]bool{}
+
+	for _, label := range current {
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		if managed[label] && !matched[label] {
+			changeSet.Removed = append(changeSet.Removed, label)
+			continue
+		}
+		changeSet.Kept = append(changeSet.Kept, label)
+	}
+
+	for label := range matched {
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		changeSet.Added = append(changeSet.Added, label)
+	}
+
+	return changeSet, nil
+}
@@ -0,0 +1,116 @@
+package labeler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPreviewEventDoesNotMutate(t *testing.T) {
+	payload, err := loadPayload("create_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replaceCalled := false
+	l := Labeler{
+		FetchRepoConfig: func(owner, repoName string) (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{
+				Version: 1,
+				Labels:  []LabelMatcher{{Label: "WIP", Title: "^WIP:.*"}},
+			}, nil
+		},
+		GetCurrentLabels: func(owner, repoName string, prNumber int) ([]string, error) {
+			return []string{"Fix"}, nil
+		},
+		ReplaceLabelsForPr: func(owner, repoName string, prNumber int, labels []string) error {
+			replaceCalled = true
+			return nil
+		},
+	}
+
+	changeSet, err := l.PreviewEvent("pull_request", &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaceCalled {
+		t.Fatal("PreviewEvent must never call ReplaceLabelsForPr")
+	}
+
+	sort.Strings(changeSet.Added)
+	sort.Strings(changeSet.Kept)
+	if !reflect.DeepEqual(changeSet.Added, []string{"WIP"}) {
+		t.Fatalf("expected Added [WIP], got %+v", changeSet.Added)
+	}
+	if !reflect.DeepEqual(changeSet.Kept, []string{"Fix"}) {
+		t.Fatalf("expected Kept [Fix], got %+v", changeSet.Kept)
+	}
+	if len(changeSet.Removed) != 0 {
+		t.Fatalf("expected nothing removed, got %+v", changeSet.Removed)
+	}
+	if rules, ok := changeSet.MatchedRules["WIP"]; !ok || len(rules) != 1 {
+		t.Fatalf("expected MatchedRules to explain WIP, got %+v", changeSet.MatchedRules)
+	}
+}
+
+func TestPreviewEventSizeConditionsFailClosedOnNonPREvents(t *testing.T) {
+	payload, err := loadPayload("open_issue")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := Labeler{
+		FetchRepoConfig: func(owner, repoName string) (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{
+				Version: 1,
+				Labels:  []LabelMatcher{{Label: "XS", SizeBelow: "50"}},
+			}, nil
+		},
+		GetCurrentLabels: func(owner, repoName string, prNumber int) ([]string, error) {
+			return []string{}, nil
+		},
+		ReplaceLabelsForPr: func(owner, repoName string, prNumber int, labels []string) error {
+			return nil
+		},
+	}
+
+	changeSet, err := l.PreviewEvent("issues", &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changeSet.Added) != 0 {
+		t.Fatalf("expected SizeBelow not to match an event without a size, got Added %+v", changeSet.Added)
+	}
+}
+
+func TestHandleEventDryRunSkipsMutation(t *testing.T) {
+	payload, err := loadPayload("create_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replaceCalled := false
+	l := Labeler{
+		DryRun: true,
+		FetchRepoConfig: func(owner, repoName string) (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{
+				Version: 1,
+				Labels:  []LabelMatcher{{Label: "WIP", Title: "^WIP:.*"}},
+			}, nil
+		},
+		GetCurrentLabels: func(owner, repoName string, prNumber int) ([]string, error) {
+			return []string{}, nil
+		},
+		ReplaceLabelsForPr: func(owner, repoName string, prNumber int, labels []string) error {
+			replaceCalled = true
+			return nil
+		},
+	}
+
+	if err := l.HandleEvent("pull_request", &payload); err != nil {
+		t.Fatal(err)
+	}
+	if replaceCalled {
+		t.Fatal("HandleEvent must not call ReplaceLabelsForPr when DryRun is set")
+	}
+}
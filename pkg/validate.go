@@ -0,0 +1,151 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationRules controls what Validate and ValidateConfig accept as a
+// well-formed label name. Passed to ValidateConfig, the zero value only
+// checks for duplicate matchers; a Labeler whose ValidationRules is the
+// zero value uses DefaultValidationRules instead.
+type ValidationRules struct {
+	// MaxLabelLength is the longest a Label may be. 0 means unbounded.
+	MaxLabelLength int
+	// AllowedChars, when set, every Label must fully match.
+	AllowedChars *regexp.Regexp
+	// RequiredPrefixes, when non-empty, requires every Label to start
+	// with at least one of these prefixes (e.g. "area/", "type/").
+	RequiredPrefixes []string
+	// ReservedNames may never be used as a Label, regardless of the
+	// other rules.
+	ReservedNames []string
+}
+
+// DefaultValidationRules are the rules applied when a Labeler does not
+// set its own ValidationRules: labels up to 50 characters, restricted to
+// letters, digits, '-', '_' and '/', with no required prefix and no
+// reserved names. Callers that want Kubernetes-style lowercase,
+// prefixed label names (e.g. "area/", "type/") should set their own,
+// stricter ValidationRules.
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		MaxLabelLength: 50,
+		AllowedChars:   regexp.MustCompile(`^[A-Za-z0-9/_-]+$`),
+	}
+}
+
+// Violation is a single offense found while validating a config.
+type Violation struct {
+	Label  string
+	Reason string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%q: %s", v.Label, v.Reason)
+}
+
+// ConfigValidationError reports every offense found in a config, rather
+// than failing on the first one, so a CI lint run can surface them all
+// at once.
+type ConfigValidationError struct {
+	Violations []Violation
+}
+
+func (e *ConfigValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return fmt.Sprintf("labeler: invalid config (%d violation(s)): %s", len(e.Violations), strings.Join(parts, "; "))
+}
+
+// ValidateConfig checks every matcher in cfg against rules and returns a
+// *ConfigValidationError listing all offenses, or nil if cfg is clean.
+func ValidateConfig(cfg *LabelerConfigV1, rules ValidationRules) error {
+	if cfg == nil {
+		return nil
+	}
+
+	var violations []Violation
+	reserved := map[string]bool{}
+	for _, name := range rules.ReservedNames {
+		reserved[name] = true
+	}
+	seenKeys := map[string]bool{}
+
+	for _, m := range cfg.Labels {
+		if reserved[m.Label] {
+			violations = append(violations, Violation{m.Label, "label name is reserved"})
+		}
+
+		if rules.MaxLabelLength > 0 && len(m.Label) > rules.MaxLabelLength {
+			violations = append(violations, Violation{
+				m.Label,
+				fmt.Sprintf("exceeds max length of %d characters", rules.MaxLabelLength),
+			})
+		}
+
+		if rules.AllowedChars != nil && !rules.AllowedChars.MatchString(m.Label) {
+			violations = append(violations, Violation{m.Label, "contains characters outside the allowed set"})
+		}
+
+		if len(rules.RequiredPrefixes) > 0 && !hasAnyPrefix(m.Label, rules.RequiredPrefixes) {
+			violations = append(violations, Violation{
+				m.Label,
+				fmt.Sprintf("missing a required prefix (one of %s)", strings.Join(rules.RequiredPrefixes, ", ")),
+			})
+		}
+
+		key := m.Label + "\x1e" + m.matcherKey()
+		if seenKeys[key] {
+			violations = append(violations, Violation{m.Label, "duplicate matcher (same label and conditions declared twice)"})
+		}
+		seenKeys[key] = true
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ConfigValidationError{Violations: violations}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveValidationRules returns l.ValidationRules, falling back to
+// DefaultValidationRules when the caller left it unset.
+func (l *Labeler) effectiveValidationRules() ValidationRules {
+	if l.ValidationRules.MaxLabelLength == 0 && l.ValidationRules.AllowedChars == nil &&
+		len(l.ValidationRules.RequiredPrefixes) == 0 && len(l.ValidationRules.ReservedNames) == 0 {
+		return DefaultValidationRules()
+	}
+	return l.ValidationRules
+}
+
+// Validate lints cfg against the Labeler's validation rules without
+// touching any PR. It is the entry point CI uses to check
+// .github/labeler.yml without running against a real webhook payload.
+func (l *Labeler) Validate(cfg *LabelerConfigV1) error {
+	return ValidateConfig(cfg, l.effectiveValidationRules())
+}
+
+// violatingLabels extracts the set of Label names flagged by err, if err
+// is a *ConfigValidationError. Used by permissive mode to drop only the
+// offending matchers instead of refusing the whole config.
+func violatingLabels(err error) map[string]bool {
+	labels := map[string]bool{}
+	if cerr, ok := err.(*ConfigValidationError); ok {
+		for _, v := range cerr.Violations {
+			labels[v.Label] = true
+		}
+	}
+	return labels
+}
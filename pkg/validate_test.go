@@ -0,0 +1,108 @@
+package labeler
+
+import (
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	rules := ValidationRules{
+		MaxLabelLength:   10,
+		RequiredPrefixes: []string{"area/", "type/"},
+		ReservedNames:    []string{"duplicate"},
+	}
+
+	cfg := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "area/api", Title: "^api:.*"},             // ok
+			{Label: "no-prefix", Title: "^x.*"},               // missing prefix
+			{Label: "type/way-too-long-label", Title: "^y.*"}, // too long (and has prefix)
+			{Label: "duplicate", Title: "^z.*"},               // reserved
+			{Label: "area/api", Title: "^api:.*"},             // duplicate matcher
+		},
+	}
+
+	err := ValidateConfig(cfg, rules)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+
+	cerr, ok := err.(*ConfigValidationError)
+	if !ok {
+		t.Fatalf("expected *ConfigValidationError, got %T", err)
+	}
+
+	// missing prefix, too long, reserved, missing prefix (on "duplicate"
+	// too), duplicate matcher: 5 offenses.
+	if len(cerr.Violations) != 5 {
+		t.Fatalf("expected 5 violations, got %d: %v", len(cerr.Violations), cerr.Violations)
+	}
+}
+
+func TestValidateConfigDoesNotFlagDistinctAnyOfMatchersAsDuplicates(t *testing.T) {
+	cfg := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "Backend", AnyOf: []LabelMatcher{{Files: []string{"^api/.*"}}}},
+			{Label: "Backend", AnyOf: []LabelMatcher{{Files: []string{"^db/.*"}}}},
+		},
+	}
+
+	if err := ValidateConfig(cfg, DefaultValidationRules()); err != nil {
+		t.Fatalf("expected no violations, got: %v", err)
+	}
+}
+
+func TestValidateConfigClean(t *testing.T) {
+	cfg := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "WIP", Title: "^WIP:.*"},
+			{Label: "Fix", Title: "^Fix:.*"},
+		},
+	}
+
+	if err := ValidateConfig(cfg, DefaultValidationRules()); err != nil {
+		t.Fatalf("expected no violations, got: %v", err)
+	}
+}
+
+func TestLabelerValidatePermissiveSkipsOnlyOffendingMatchers(t *testing.T) {
+	l := &Labeler{
+		ValidationRules: ValidationRules{RequiredPrefixes: []string{"area/"}},
+	}
+
+	cfg := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "area/api", Title: "^api:.*"},
+			{Label: "bad", Title: "^x.*"},
+		},
+	}
+
+	cleaned, err := l.validateOrFilter(cfg)
+	if err != nil {
+		t.Fatalf("permissive mode should not return an error, got: %v", err)
+	}
+	if len(cleaned.Labels) != 1 || cleaned.Labels[0].Label != "area/api" {
+		t.Fatalf("expected only the compliant matcher to survive, got: %+v", cleaned.Labels)
+	}
+}
+
+func TestLabelerValidateStrictRefusesWholeConfig(t *testing.T) {
+	l := &Labeler{
+		StrictValidation: true,
+		ValidationRules:  ValidationRules{RequiredPrefixes: []string{"area/"}},
+	}
+
+	cfg := &LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{Label: "bad", Title: "^x.*"},
+		},
+	}
+
+	if _, err := l.validateOrFilter(cfg); err == nil {
+		t.Fatal("expected strict mode to refuse the config")
+	}
+}